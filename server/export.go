@@ -0,0 +1,357 @@
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/cosmos/cosmos-sdk/server/types"
+)
+
+const (
+	// FlagHeight is the height at which to export the app's state.
+	FlagHeight = "height"
+	// FlagForZeroHeight normalizes the exported state to begin at height 0.
+	FlagForZeroHeight = "for-zero-height"
+	// FlagJailAllowedAddrs lists validator operator addresses exempt from
+	// being jailed during a zero-height export.
+	FlagJailAllowedAddrs = "jail-allowed-addrs"
+	// FlagOutputFormat selects how app_state is written: "json" (the
+	// default, a single materialized JSON document), "json-stream" (the
+	// GenesisDoc skeleton followed by app_state streamed key-by-key), or
+	// "tar.gz" (one archive entry per module plus the GenesisDoc skeleton).
+	FlagOutputFormat = "output-format"
+
+	// OutputFormatJSON is the default, fully-buffered export format.
+	OutputFormatJSON = "json"
+	// OutputFormatJSONStream streams app_state to stdout without
+	// materializing the full map in memory.
+	OutputFormatJSONStream = "json-stream"
+	// OutputFormatTarGz writes one archive entry per module's genesis.
+	OutputFormatTarGz = "tar.gz"
+
+	// flagTarGzOutputDir is the directory the tar.gz archive is written to;
+	// unrelated to TestnetCmd's own --output-dir flag, which names a
+	// different directory on a different command.
+	flagTarGzOutputDir = "output-dir"
+)
+
+// ExportCmd dumps app state to JSON. streamingExporter is optional and
+// variadic only so existing two-argument call sites keep compiling
+// unchanged; at most one should ever be passed, and omitting it (or passing
+// nil) is equivalent to an app with no types.GenesisStreamer-implementing
+// modules, where "json-stream"/"tar.gz" fall back to the same
+// fully-buffered AppState the default "json" format uses. Apps with large
+// modules (e.g. millions of bank balances) should supply one so those
+// modules write straight to the output instead of being held in memory as a
+// single json.RawMessage first.
+func ExportCmd(appExporter types.AppExporter, defaultNodeHome string, streamingExporter ...types.StreamingAppExporter) *cobra.Command {
+	var streamExporter types.StreamingAppExporter
+	if len(streamingExporter) > 0 {
+		streamExporter = streamingExporter[0]
+	}
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export state to JSON",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverCtx := GetServerContextFromCmd(cmd)
+			config := serverCtx.Config
+
+			homeDir, _ := cmd.Flags().GetString(flags.FlagHome)
+			config.SetRoot(homeDir)
+
+			if _, err := os.Stat(config.GenesisFile()); os.IsNotExist(err) {
+				return err
+			}
+
+			db, err := openDB(homeDir)
+			if err != nil {
+				return err
+			}
+
+			if appExporter == nil {
+				if _, err := fmt.Fprintln(cmd.ErrOrStderr(), "WARNING: App exporter not defined. Returning genesis file."); err != nil {
+					return err
+				}
+
+				genesis, err := ioutil.ReadFile(config.GenesisFile())
+				if err != nil {
+					return err
+				}
+
+				cmd.Println(string(genesis))
+				return nil
+			}
+
+			traceWriterFile, _ := cmd.Flags().GetString(flagTraceStore)
+			traceWriter, err := openTraceWriter(traceWriterFile)
+			if err != nil {
+				return err
+			}
+
+			height, _ := cmd.Flags().GetInt64(FlagHeight)
+			forZeroHeight, _ := cmd.Flags().GetBool(FlagForZeroHeight)
+			jailAllowedAddrs, _ := cmd.Flags().GetStringSlice(FlagJailAllowedAddrs)
+			outputFormat, _ := cmd.Flags().GetString(FlagOutputFormat)
+
+			var (
+				exported  types.ExportedApp
+				streamers types.GenesisStreamers
+			)
+
+			// Only ask for streamers when the output format can actually use
+			// them; the plain "json" format has nowhere to put a streamed
+			// module other than back into one big buffer, so there's no
+			// point bypassing the ordinary AppExporter for it.
+			if streamExporter != nil && (outputFormat == OutputFormatJSONStream || outputFormat == OutputFormatTarGz) {
+				exported, streamers, err = streamExporter(serverCtx.Logger, db, traceWriter, height, forZeroHeight, jailAllowedAddrs, serverCtx.Viper)
+			} else {
+				exported, err = appExporter(serverCtx.Logger, db, traceWriter, height, forZeroHeight, jailAllowedAddrs, serverCtx.Viper)
+			}
+			if err != nil {
+				return fmt.Errorf("error exporting state: %v", err)
+			}
+
+			doc, err := tmtypes.GenesisDocFromFile(config.GenesisFile())
+			if err != nil {
+				return err
+			}
+
+			doc.Validators = exported.Validators
+			doc.InitialHeight = exported.Height
+
+			// Mutate in place rather than replacing ConsensusParams wholesale:
+			// it preserves fields like Block.TimeIotaMs that only exist on the
+			// Tendermint-level genesis doc and have no ABCI counterpart on
+			// exported.ConsensusParams.
+			doc.ConsensusParams.Block.MaxBytes = exported.ConsensusParams.Block.MaxBytes
+			doc.ConsensusParams.Block.MaxGas = exported.ConsensusParams.Block.MaxGas
+			doc.ConsensusParams.Evidence = exported.ConsensusParams.Evidence
+			doc.ConsensusParams.Validator = exported.ConsensusParams.Validator
+
+			switch outputFormat {
+			case "", OutputFormatJSON:
+				doc.AppState = exported.AppState
+
+				encoded, err := tmjson.Marshal(doc)
+				if err != nil {
+					return err
+				}
+
+				cmd.Println(string(sdk.MustSortJSON(encoded)))
+				return nil
+
+			case OutputFormatJSONStream:
+				return streamJSONGenesis(cmd.OutOrStdout(), doc, exported.AppState, streamers)
+
+			case OutputFormatTarGz:
+				outPath, _ := cmd.Flags().GetString(flagTarGzOutputDir)
+				return writeTarGzGenesis(outPath, doc, exported.AppState, streamers)
+
+			default:
+				return fmt.Errorf("unknown --%s value %q, must be one of %q, %q, %q", FlagOutputFormat, outputFormat, OutputFormatJSON, OutputFormatJSONStream, OutputFormatTarGz)
+			}
+		},
+	}
+
+	cmd.Flags().String(flags.FlagHome, defaultNodeHome, "The application home directory")
+	cmd.Flags().Int64(FlagHeight, -1, "Export state from a particular height (-1 means the latest height)")
+	cmd.Flags().Bool(FlagForZeroHeight, false, "Export state to start at height zero (perform preproccessing)")
+	cmd.Flags().StringSlice(FlagJailAllowedAddrs, []string{}, "Comma-separated list of operator addresses of jailed validators to unjail")
+	cmd.Flags().String(FlagOutputFormat, OutputFormatJSON, "Output format for app_state: json, json-stream, or tar.gz")
+	cmd.Flags().String(flagTarGzOutputDir, ".", "Directory to write the tar.gz archive to, when --output-format=tar.gz")
+
+	return cmd
+}
+
+// streamJSONGenesis writes out the GenesisDoc skeleton followed by app_state
+// written module-by-module: modules present in streamers write their
+// genesis straight to w via ExportGenesisStream, and never exist as a Go
+// value at all; every other module comes from the already-unmarshaled
+// exportedAppState, exactly like the plain "json" format.
+func streamJSONGenesis(w io.Writer, doc *tmtypes.GenesisDoc, exportedAppState json.RawMessage, streamers types.GenesisStreamers) error {
+	var appState map[string]json.RawMessage
+	if err := json.Unmarshal(exportedAppState, &appState); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, `{"genesis_time":%q,"chain_id":%q,"initial_height":%d,"consensus_params":`,
+		doc.GenesisTime.Format("2006-01-02T15:04:05.000000000Z"), doc.ChainID, doc.InitialHeight); err != nil {
+		return err
+	}
+
+	consensusParamsJSON, err := tmjson.Marshal(doc.ConsensusParams)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(consensusParamsJSON); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, `,"app_state":{`); err != nil {
+		return err
+	}
+
+	for i, name := range moduleNames(appState, streamers) {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+
+		nameJSON, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(nameJSON); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, ":"); err != nil {
+			return err
+		}
+
+		if streamer, ok := streamers[name]; ok {
+			if err := streamer.ExportGenesisStream(sdk.Context{}, w); err != nil {
+				return fmt.Errorf("streaming module %q genesis: %w", name, err)
+			}
+			continue
+		}
+
+		if _, err := w.Write(appState[name]); err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(w, "}}\n")
+	return err
+}
+
+// writeTarGzGenesis writes a tar.gz archive to outDir/genesis-export.tar.gz
+// containing the GenesisDoc skeleton (genesis-skeleton.json) plus one entry
+// per module's genesis (app_state/<module>.json). Modules present in
+// streamers write their genesis to a temporary file via ExportGenesisStream
+// and are copied into the archive from there, so their genesis is never held
+// in memory as a whole; every other module comes from the already-unmarshaled
+// exportedAppState, exactly like the plain "json" format.
+func writeTarGzGenesis(outDir string, doc *tmtypes.GenesisDoc, exportedAppState json.RawMessage, streamers types.GenesisStreamers) error {
+	var appState map[string]json.RawMessage
+	if err := json.Unmarshal(exportedAppState, &appState); err != nil {
+		return err
+	}
+
+	f, err := os.Create(outDir + "/genesis-export.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gzw := gzip.NewWriter(f)
+	defer gzw.Close()
+
+	tw := tar.NewWriter(gzw)
+	defer tw.Close()
+
+	skeleton := *doc
+	skeleton.AppState = nil
+	skeletonJSON, err := tmjson.Marshal(skeleton)
+	if err != nil {
+		return err
+	}
+
+	if err := writeTarEntry(tw, "genesis-skeleton.json", skeletonJSON); err != nil {
+		return err
+	}
+
+	for _, name := range moduleNames(appState, streamers) {
+		entryName := "app_state/" + name + ".json"
+
+		if streamer, ok := streamers[name]; ok {
+			if err := writeStreamedTarEntry(tw, entryName, streamer); err != nil {
+				return fmt.Errorf("streaming module %q genesis: %w", name, err)
+			}
+			continue
+		}
+
+		if err := writeTarEntry(tw, entryName, appState[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moduleNames returns the sorted union of appState's keys and streamers'
+// keys, so callers can walk every module exactly once regardless of which
+// of the two it came from.
+func moduleNames(appState map[string]json.RawMessage, streamers types.GenesisStreamers) []string {
+	names := make([]string, 0, len(appState)+len(streamers))
+	for name := range appState {
+		names = append(names, name)
+	}
+	for name := range streamers {
+		if _, buffered := appState[name]; !buffered {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeTarEntry(tw *tar.Writer, name string, contents []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: 0o644,
+		Size: int64(len(contents)),
+	}); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(contents)
+	return err
+}
+
+// writeStreamedTarEntry runs streamer against a temporary file rather than
+// an in-memory buffer, since a tar header must declare its entry's size up
+// front; the temp file is removed once its contents have been copied into
+// the archive.
+func writeStreamedTarEntry(tw *tar.Writer, name string, streamer types.GenesisStreamer) error {
+	tmp, err := ioutil.TempFile("", "genesis-stream-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := streamer.ExportGenesisStream(sdk.Context{}, tmp); err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: size}); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, tmp)
+	return err
+}
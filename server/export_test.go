@@ -126,7 +126,195 @@ func TestExportCmd_Height(t *testing.T) {
 
 }
 
-func setupApp(t *testing.T, tempDir string) (*simapp.SimApp, context.Context, *tmtypes.GenesisDoc, *cobra.Command) {
+// TestExportCmd_JSONStreamRoundTrip is a correctness smoke test for
+// --output-format=json-stream: every module in the original genesis must
+// still be present after a round trip through the streaming encoder. This
+// app has no types.GenesisStreamer-implementing modules, so it exercises
+// only the fully-buffered fallback path; the memory-budget regression test
+// for an actually-streamed module lives in
+// TestStreamJSONGenesis_LargeModuleStaysWithinMemoryBudget in
+// export_internal_test.go, which can reach the unexported streamJSONGenesis
+// directly.
+func TestExportCmd_JSONStreamRoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	_, ctx, genDoc, cmd := setupApp(t, tempDir)
+
+	output := &bytes.Buffer{}
+	cmd.SetOut(output)
+	cmd.SetArgs([]string{
+		fmt.Sprintf("--%s=%s", flags.FlagHome, tempDir),
+		fmt.Sprintf("--%s=%s", server.FlagOutputFormat, server.OutputFormatJSONStream),
+	})
+	require.NoError(t, cmd.ExecuteContext(ctx))
+
+	var exportedAppState map[string]json.RawMessage
+	var rawDoc map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(output.Bytes(), &rawDoc))
+	require.Contains(t, rawDoc, "app_state")
+	require.NoError(t, json.Unmarshal(rawDoc["app_state"], &exportedAppState))
+
+	var originalAppState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(genDoc.AppState, &originalAppState))
+
+	for module := range originalAppState {
+		require.Contains(t, exportedAppState, module)
+	}
+}
+
+// TestFilteredExportCmd_ModuleAllowlist exports only bank+auth and asserts
+// every other module is gone from the resulting genesis.
+func TestFilteredExportCmd_ModuleAllowlist(t *testing.T) {
+	tempDir := t.TempDir()
+	_, ctx, _, cmd := setupFilteredApp(t, tempDir)
+
+	output := &bytes.Buffer{}
+	cmd.SetOut(output)
+	cmd.SetArgs([]string{
+		fmt.Sprintf("--%s=%s", flags.FlagHome, tempDir),
+		fmt.Sprintf("--modules=%s,%s", authtypes.ModuleName, banktypes.ModuleName),
+	})
+	require.NoError(t, cmd.ExecuteContext(ctx))
+
+	var exportedGenDoc tmtypes.GenesisDoc
+	require.NoError(t, tmjson.Unmarshal(output.Bytes(), &exportedGenDoc))
+
+	var appState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(exportedGenDoc.AppState, &appState))
+	require.Len(t, appState, 2)
+	require.Contains(t, appState, authtypes.ModuleName)
+	require.Contains(t, appState, banktypes.ModuleName)
+}
+
+// TestFilteredExportCmd_BogusModuleName checks that a --modules entry which
+// names no real module leaves the export empty of module state rather than
+// erroring, mirroring the existing ExportCmd's "best-effort" flag handling:
+// filterAppState's allowlist is a plain set membership check with nothing to
+// fail on a name that doesn't match any module.
+func TestFilteredExportCmd_BogusModuleName(t *testing.T) {
+	tempDir := t.TempDir()
+	_, ctx, _, cmd := setupFilteredApp(t, tempDir)
+
+	output := &bytes.Buffer{}
+	cmd.SetOut(output)
+	cmd.SetArgs([]string{
+		fmt.Sprintf("--%s=%s", flags.FlagHome, tempDir),
+		"--modules=this-module-does-not-exist",
+	})
+	require.NoError(t, cmd.ExecuteContext(ctx))
+
+	var exportedGenDoc tmtypes.GenesisDoc
+	require.NoError(t, tmjson.Unmarshal(output.Bytes(), &exportedGenDoc))
+
+	var appState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(exportedGenDoc.AppState, &appState))
+	require.Empty(t, appState)
+}
+
+// TestFilteredExportCmd_PruneIBC checks --prune-ibc drops both ibc and
+// capability from the export while every other module survives.
+func TestFilteredExportCmd_PruneIBC(t *testing.T) {
+	tempDir := t.TempDir()
+	_, ctx, genDoc, cmd := setupFilteredApp(t, tempDir)
+
+	var originalAppState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(genDoc.AppState, &originalAppState))
+
+	output := &bytes.Buffer{}
+	cmd.SetOut(output)
+	cmd.SetArgs([]string{
+		fmt.Sprintf("--%s=%s", flags.FlagHome, tempDir),
+		"--prune-ibc",
+	})
+	require.NoError(t, cmd.ExecuteContext(ctx))
+
+	var exportedGenDoc tmtypes.GenesisDoc
+	require.NoError(t, tmjson.Unmarshal(output.Bytes(), &exportedGenDoc))
+
+	var appState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(exportedGenDoc.AppState, &appState))
+	require.NotContains(t, appState, "ibc")
+	require.NotContains(t, appState, "capability")
+
+	wantLen := len(originalAppState)
+	if _, ok := originalAppState["ibc"]; ok {
+		wantLen--
+	}
+	if _, ok := originalAppState["capability"]; ok {
+		wantLen--
+	}
+	require.Len(t, appState, wantLen)
+}
+
+// TestFilteredExportCmd_ExcludeModules drops bank from the export and checks
+// it (and only it) is gone while every other module survives.
+func TestFilteredExportCmd_ExcludeModules(t *testing.T) {
+	tempDir := t.TempDir()
+	_, ctx, genDoc, cmd := setupFilteredApp(t, tempDir)
+
+	var originalAppState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(genDoc.AppState, &originalAppState))
+
+	output := &bytes.Buffer{}
+	cmd.SetOut(output)
+	cmd.SetArgs([]string{
+		fmt.Sprintf("--%s=%s", flags.FlagHome, tempDir),
+		fmt.Sprintf("--exclude-modules=%s", banktypes.ModuleName),
+	})
+	require.NoError(t, cmd.ExecuteContext(ctx))
+
+	var exportedGenDoc tmtypes.GenesisDoc
+	require.NoError(t, tmjson.Unmarshal(output.Bytes(), &exportedGenDoc))
+
+	var appState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(exportedGenDoc.AppState, &appState))
+	require.NotContains(t, appState, banktypes.ModuleName)
+	require.Len(t, appState, len(originalAppState)-1)
+}
+
+// TestFilteredExportCmd_RedactFields redacts the bank module's "params"
+// field and checks it is gone from the export while the rest of bank's
+// genesis (e.g. supply) survives untouched. Redacting "balances" instead
+// would leave a supply that no longer matches the balances, which bank's
+// ValidateGenesis rejects, so the command would refuse the export.
+func TestFilteredExportCmd_RedactFields(t *testing.T) {
+	tempDir := t.TempDir()
+	_, ctx, _, cmd := setupFilteredApp(t, tempDir)
+
+	output := &bytes.Buffer{}
+	cmd.SetOut(output)
+	cmd.SetArgs([]string{
+		fmt.Sprintf("--%s=%s", flags.FlagHome, tempDir),
+		fmt.Sprintf("--modules=%s", banktypes.ModuleName),
+		fmt.Sprintf("--redact-fields=%s:params", banktypes.ModuleName),
+	})
+	require.NoError(t, cmd.ExecuteContext(ctx))
+
+	var exportedGenDoc tmtypes.GenesisDoc
+	require.NoError(t, tmjson.Unmarshal(output.Bytes(), &exportedGenDoc))
+
+	var appState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(exportedGenDoc.AppState, &appState))
+
+	var bankState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(appState[banktypes.ModuleName], &bankState))
+	require.NotContains(t, bankState, "params")
+	require.Contains(t, bankState, "supply")
+}
+
+// exportFixture is the SimApp/genesis bootstrap shared by setupApp and
+// setupFilteredApp: one funded account, one validator, committed at height
+// 1. The two setup functions differ only in which command they build on top
+// of it, so this is the single place that fixture lives.
+type exportFixture struct {
+	app       *simapp.SimApp
+	db        dbm.DB
+	logger    log.Logger
+	genDoc    *tmtypes.GenesisDoc
+	serverCtx *server.Context
+	clientCtx client.Context
+}
+
+func bootstrapExportFixture(t *testing.T, tempDir string) *exportFixture {
 	t.Helper()
 
 	if err := createConfigFolder(tempDir); err != nil {
@@ -137,11 +325,9 @@ func setupApp(t *testing.T, tempDir string) (*simapp.SimApp, context.Context, *t
 	pubKey, err := privVal.GetPubKey()
 	require.NoError(t, err)
 
-	// create validator set with single validator
 	validator := tmtypes.NewValidator(pubKey, 1)
 	valSet := tmtypes.NewValidatorSet([]*tmtypes.Validator{validator})
 
-	// generate genesis account
 	senderPrivKey := secp256k1.GenPrivKey()
 	acc := authtypes.NewBaseAccount(senderPrivKey.PubKey().Address().Bytes(), senderPrivKey.PubKey(), 0, 0)
 	balances := []banktypes.Balance{
@@ -156,54 +342,92 @@ func setupApp(t *testing.T, tempDir string) (*simapp.SimApp, context.Context, *t
 	encCfg := simapp.MakeTestEncodingConfig()
 	app := simapp.NewSimApp(logger, db, nil, true, map[int64]bool{}, tempDir, 0, encCfg, simapp.EmptyAppOptions{})
 	genesisState := simapp.NewDefaultGenesisState(encCfg.Codec)
-	genAccs := []authtypes.GenesisAccount{acc}
-
-	genesisState = simapp.SetupGenesisStateWithValSet(t, app.AppCodec(), genesisState, valSet, genAccs, balances...)
+	genesisState = simapp.SetupGenesisStateWithValSet(t, app.AppCodec(), genesisState, valSet, []authtypes.GenesisAccount{acc}, balances...)
 	stateBytes, err := json.MarshalIndent(genesisState, "", " ")
 	require.NoError(t, err)
 
 	serverCtx := server.NewDefaultContext()
 	serverCtx.Config.RootDir = tempDir
 
-	clientCtx := client.Context{}.WithCodec(app.AppCodec())
 	genDoc := &tmtypes.GenesisDoc{}
 	genDoc.ChainID = "theChainId"
 	genDoc.Validators = nil
 	genDoc.AppState = stateBytes
 
 	require.NoError(t, saveGenesisFile(genDoc, serverCtx.Config.GenesisFile()))
-	app.InitChain(
-		abci.RequestInitChain{
-			Validators:      []abci.ValidatorUpdate{},
-			ConsensusParams: simapp.DefaultConsensusParams,
-			AppStateBytes:   genDoc.AppState,
-		},
-	)
+	app.InitChain(abci.RequestInitChain{
+		Validators:      []abci.ValidatorUpdate{},
+		ConsensusParams: simapp.DefaultConsensusParams,
+		AppStateBytes:   genDoc.AppState,
+	})
 	app.Commit()
 
-	cmd := server.ExportCmd(
-		func(_ log.Logger, _ dbm.DB, _ io.Writer, height int64, forZeroHeight bool, jailAllowedAddrs []string, appOptons types.AppOptions) (types.ExportedApp, error) {
-			encCfg := simapp.MakeTestEncodingConfig()
-
-			var simApp *simapp.SimApp
-			if height != -1 {
-				simApp = simapp.NewSimApp(logger, db, nil, false, map[int64]bool{}, "", 0, encCfg, appOptons)
+	clientCtx := client.Context{}.
+		WithCodec(app.AppCodec()).
+		WithTxConfig(encCfg.TxConfig).
+		WithHomeDir(tempDir)
+
+	return &exportFixture{
+		app:       app,
+		db:        db,
+		logger:    logger,
+		genDoc:    genDoc,
+		serverCtx: serverCtx,
+		clientCtx: clientCtx,
+	}
+}
 
-				if err := simApp.LoadHeight(height); err != nil {
-					return types.ExportedApp{}, err
-				}
-			} else {
-				simApp = simapp.NewSimApp(logger, db, nil, true, map[int64]bool{}, "", 0, encCfg, appOptons)
+// legacyExporter builds a types.AppExporter closure that reopens f's db at
+// the requested height (or the live app, for the latest height) and
+// delegates to SimApp's own ExportAppStateAndValidators.
+func (f *exportFixture) legacyExporter() func(_ log.Logger, _ dbm.DB, _ io.Writer, height int64, forZeroHeight bool, jailAllowedAddrs []string, appOpts types.AppOptions) (types.ExportedApp, error) {
+	return func(_ log.Logger, _ dbm.DB, _ io.Writer, height int64, forZeroHeight bool, jailAllowedAddrs []string, appOpts types.AppOptions) (types.ExportedApp, error) {
+		encCfg := simapp.MakeTestEncodingConfig()
+
+		var simApp *simapp.SimApp
+		if height != -1 {
+			simApp = simapp.NewSimApp(f.logger, f.db, nil, false, map[int64]bool{}, "", 0, encCfg, appOpts)
+			if err := simApp.LoadHeight(height); err != nil {
+				return types.ExportedApp{}, err
 			}
+		} else {
+			simApp = simapp.NewSimApp(f.logger, f.db, nil, true, map[int64]bool{}, "", 0, encCfg, appOpts)
+		}
 
-			return simApp.ExportAppStateAndValidators(forZeroHeight, jailAllowedAddrs)
-		}, tempDir)
+		return simApp.ExportAppStateAndValidators(forZeroHeight, jailAllowedAddrs)
+	}
+}
 
+// context builds the context.Context ExportCmd/FilteredExportCmd expect,
+// carrying both the client and server contexts setupApp's callers pull their
+// cmd.ExecuteContext from.
+func (f *exportFixture) context() context.Context {
 	ctx := context.Background()
-	ctx = context.WithValue(ctx, client.ClientContextKey, &clientCtx)
-	ctx = context.WithValue(ctx, server.ServerContextKey, serverCtx)
+	ctx = context.WithValue(ctx, client.ClientContextKey, &f.clientCtx)
+	ctx = context.WithValue(ctx, server.ServerContextKey, f.serverCtx)
+	return ctx
+}
+
+// setupFilteredApp mirrors setupApp, but wires the fixture's legacy exporter
+// closure into server.FilteredExportCmd via types.WrapAppExporter instead of
+// server.ExportCmd, so module-allowlist/denylist/redact-field tests exercise
+// the exact same SimApp/export code path the ExportCmd tests above do.
+func setupFilteredApp(t *testing.T, tempDir string) (*simapp.SimApp, context.Context, *tmtypes.GenesisDoc, *cobra.Command) {
+	t.Helper()
+
+	f := bootstrapExportFixture(t, tempDir)
+	cmd := server.FilteredExportCmd(types.WrapAppExporter(f.legacyExporter()), simapp.ModuleBasics, tempDir)
+
+	return f.app, f.context(), f.genDoc, cmd
+}
+
+func setupApp(t *testing.T, tempDir string) (*simapp.SimApp, context.Context, *tmtypes.GenesisDoc, *cobra.Command) {
+	t.Helper()
+
+	f := bootstrapExportFixture(t, tempDir)
+	cmd := server.ExportCmd(f.legacyExporter(), tempDir)
 
-	return app, ctx, genDoc, cmd
+	return f.app, f.context(), f.genDoc, cmd
 }
 
 func createConfigFolder(dir string) error {
@@ -0,0 +1,250 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/server/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+)
+
+const (
+	flagModules        = "modules"
+	flagExcludeModules = "exclude-modules"
+	flagPruneIBC       = "prune-ibc"
+	flagRedactFields   = "redact-fields"
+)
+
+// FilteredExportCmd is a sibling of ExportCmd for operators who only need a
+// subset of chain state: exporting a fork-prep snapshot or a forensic dump
+// of a couple of modules shouldn't require materializing (and publishing)
+// the whole world. It refuses to emit a genesis.json unless the resulting,
+// filtered AppState still passes every remaining module's ValidateGenesis.
+func FilteredExportCmd(appExporter types.FilteredAppExporter, mbm module.BasicManager, defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-filtered",
+		Short: "Export a filtered subset of state to JSON",
+		Long: `export-filtered behaves like export, but additionally accepts
+--modules, --exclude-modules, --prune-ibc and --redact-fields so operators can
+produce a partial genesis.json for forensic inspection or fork preparation
+instead of dumping the entire chain state. --redact-fields drops whole
+top-level JSON fields by name prefix from a module's genesis (e.g. all of
+"balances"); it does not redact individual store entries by key, which
+would require the app's own FilteredAppExporter to do during its export.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverCtx := GetServerContextFromCmd(cmd)
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			config := serverCtx.Config
+
+			homeDir, _ := cmd.Flags().GetString(flags.FlagHome)
+			config.SetRoot(homeDir)
+
+			db, err := openDB(homeDir)
+			if err != nil {
+				return err
+			}
+
+			traceWriterFile, _ := cmd.Flags().GetString(flagTraceStore)
+			traceWriter, err := openTraceWriter(traceWriterFile)
+			if err != nil {
+				return err
+			}
+
+			opts, err := exportOptionsFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			exported, err := appExporter(serverCtx.Logger, db, traceWriter, opts, serverCtx.Viper)
+			if err != nil {
+				return fmt.Errorf("error exporting state: %v", err)
+			}
+
+			filteredState, err := filterAppState(exported.AppState, opts)
+			if err != nil {
+				return fmt.Errorf("error filtering app state: %v", err)
+			}
+
+			remaining, err := remainingGenesisState(filteredState)
+			if err != nil {
+				return err
+			}
+
+			// Only validate the modules still present in the filtered state:
+			// mbm.ValidateGenesis expects every module it knows about to have
+			// an entry, which by design no longer holds once --modules or
+			// --exclude-modules has dropped some of them.
+			if err := remainingModules(mbm, remaining).ValidateGenesis(clientCtx.Codec, clientCtx.TxConfig, remaining); err != nil {
+				return fmt.Errorf("filtered app state no longer validates: %v", err)
+			}
+
+			doc, err := tmtypes.GenesisDocFromFile(config.GenesisFile())
+			if err != nil {
+				return err
+			}
+
+			doc.AppState = filteredState
+			doc.Validators = exported.Validators
+			doc.InitialHeight = exported.Height
+			doc.ConsensusParams.Block.MaxBytes = exported.ConsensusParams.Block.MaxBytes
+			doc.ConsensusParams.Block.MaxGas = exported.ConsensusParams.Block.MaxGas
+			doc.ConsensusParams.Evidence = exported.ConsensusParams.Evidence
+			doc.ConsensusParams.Validator = exported.ConsensusParams.Validator
+
+			encoded, err := tmjson.Marshal(doc)
+			if err != nil {
+				return err
+			}
+
+			cmd.Println(string(encoded))
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flags.FlagHome, defaultNodeHome, "The application home directory")
+	cmd.Flags().Int64(FlagHeight, -1, "Export state from a particular height (-1 means the latest height)")
+	cmd.Flags().Bool(FlagForZeroHeight, false, "Export state to start at height zero (perform preproccessing)")
+	cmd.Flags().StringSlice(FlagJailAllowedAddrs, []string{}, "Comma-separated list of operator addresses of jailed validators to unjail")
+	cmd.Flags().StringSlice(flagModules, []string{}, "Comma-separated allowlist of modules to export; empty means every module")
+	cmd.Flags().StringSlice(flagExcludeModules, []string{}, "Comma-separated denylist of modules to drop from the export, applied after --modules")
+	cmd.Flags().Bool(flagPruneIBC, false, "Drop ibc (and its capability bookkeeping) from the export")
+	cmd.Flags().StringArray(flagRedactFields, []string{}, "module:fieldPrefix pairs; top-level genesis fields of module whose name shares that prefix are omitted (repeatable; one prefix per module, a later pair for the same module replaces an earlier one). This matches JSON field names, not raw store-key bytes: it cannot redact a single store entry that isn't its own top-level genesis field")
+
+	return cmd
+}
+
+func exportOptionsFromFlags(cmd *cobra.Command) (types.ExportOptions, error) {
+	height, _ := cmd.Flags().GetInt64(FlagHeight)
+	forZeroHeight, _ := cmd.Flags().GetBool(FlagForZeroHeight)
+	jailAllowedAddrs, _ := cmd.Flags().GetStringSlice(FlagJailAllowedAddrs)
+	modules, _ := cmd.Flags().GetStringSlice(flagModules)
+	excludeModules, _ := cmd.Flags().GetStringSlice(flagExcludeModules)
+	pruneIBC, _ := cmd.Flags().GetBool(flagPruneIBC)
+	redactFlags, _ := cmd.Flags().GetStringArray(flagRedactFields)
+
+	redactFields := make(map[string]string, len(redactFlags))
+	for _, entry := range redactFlags {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return types.ExportOptions{}, fmt.Errorf("invalid --%s entry %q, want module:fieldPrefix", flagRedactFields, entry)
+		}
+
+		redactFields[parts[0]] = parts[1]
+	}
+
+	return types.ExportOptions{
+		Height:           height,
+		ForZeroHeight:    forZeroHeight,
+		JailAllowedAddrs: jailAllowedAddrs,
+		Modules:          modules,
+		ExcludeModules:   excludeModules,
+		PruneIBC:         pruneIBC,
+		RedactFields:     redactFields,
+	}, nil
+}
+
+// filterAppState applies opts.Modules, opts.ExcludeModules, opts.PruneIBC
+// and opts.RedactFields to an already-exported AppState. All of this
+// operates on the marshaled JSON FilteredAppExporter handed back, not on a
+// module's raw KV store: FilteredExportCmd has no access to a live
+// sdk.Context or module manager, so it cannot ask a module to omit state
+// during its own export walk. That makes RedactFields a JSON-level
+// operation by construction, dropping whole top-level genesis fields rather
+// than arbitrary store key prefixes; see redactFieldPrefix.
+func filterAppState(appState json.RawMessage, opts types.ExportOptions) (json.RawMessage, error) {
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(appState, &state); err != nil {
+		return nil, err
+	}
+
+	if len(opts.Modules) > 0 {
+		allowed := make(map[string]bool, len(opts.Modules))
+		for _, m := range opts.Modules {
+			allowed[m] = true
+		}
+		for name := range state {
+			if !allowed[name] {
+				delete(state, name)
+			}
+		}
+	}
+
+	for _, m := range opts.ExcludeModules {
+		delete(state, m)
+	}
+
+	if opts.PruneIBC {
+		delete(state, "ibc")
+		delete(state, "capability")
+	}
+
+	for moduleName, fieldPrefix := range opts.RedactFields {
+		moduleState, ok := state[moduleName]
+		if !ok {
+			continue
+		}
+
+		redacted, err := redactFieldPrefix(moduleState, fieldPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("redacting module %q: %w", moduleName, err)
+		}
+
+		state[moduleName] = redacted
+	}
+
+	return json.Marshal(state)
+}
+
+// redactFieldPrefix drops every top-level field of a module's genesis
+// object whose name has the given prefix (e.g. fieldPrefix "balances" drops
+// a "balances" field but not "bond_denom"). This only ever matches against
+// JSON field names, not raw store key bytes, so it cannot redact state that
+// a module's genesis JSON doesn't expose as a like-named top-level field.
+func redactFieldPrefix(moduleStateJSON json.RawMessage, fieldPrefix string) (json.RawMessage, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(moduleStateJSON, &obj); err != nil {
+		// Not a JSON object (e.g. an array-shaped module genesis); there is
+		// no field-level prefix to redact.
+		return moduleStateJSON, nil
+	}
+
+	for key := range obj {
+		if strings.HasPrefix(key, fieldPrefix) {
+			delete(obj, key)
+		}
+	}
+
+	return json.Marshal(obj)
+}
+
+// remainingGenesisState unmarshals a filtered AppState back into the
+// map[string]json.RawMessage shape module.BasicManager.ValidateGenesis
+// expects.
+func remainingGenesisState(appState json.RawMessage) (map[string]json.RawMessage, error) {
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(appState, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// remainingModules returns the subset of mbm whose module name still has an
+// entry in remaining, so ValidateGenesis only checks modules the filter
+// actually kept.
+func remainingModules(mbm module.BasicManager, remaining map[string]json.RawMessage) module.BasicManager {
+	filtered := make(module.BasicManager, len(remaining))
+	for name := range remaining {
+		if basic, ok := mbm[name]; ok {
+			filtered[name] = basic
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// TestDecodeJSONStreamGenesis_RoundTrip feeds decodeJSONStreamGenesis the
+// output of streamJSONGenesis and checks every GenesisDoc field that format
+// carries survives the round trip, in particular GenesisTime: it is written
+// first by streamJSONGenesis but was never read back by an earlier version
+// of this function, silently resetting every json-stream import to the Go
+// zero time.
+func TestDecodeJSONStreamGenesis_RoundTrip(t *testing.T) {
+	doc := &tmtypes.GenesisDoc{
+		GenesisTime:   time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC),
+		ChainID:       "streaming-roundtrip",
+		InitialHeight: 42,
+	}
+	appState := json.RawMessage(`{"auth":{"foo":"bar"},"bank":{"baz":1}}`)
+
+	var buf bytes.Buffer
+	require.NoError(t, streamJSONGenesis(&buf, doc, appState, nil))
+
+	decodedDoc, decodedAppState, err := decodeJSONStreamGenesis(&buf)
+	require.NoError(t, err)
+
+	require.True(t, doc.GenesisTime.Equal(decodedDoc.GenesisTime),
+		"genesis_time did not round-trip: got %s, want %s", decodedDoc.GenesisTime, doc.GenesisTime)
+	require.Equal(t, doc.ChainID, decodedDoc.ChainID)
+	require.Equal(t, doc.InitialHeight, decodedDoc.InitialHeight)
+
+	var decoded map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(decodedAppState, &decoded))
+	require.JSONEq(t, `{"foo":"bar"}`, string(decoded["auth"]))
+	require.JSONEq(t, `{"baz":1}`, string(decoded["bank"]))
+}
+
+// TestDecodeTarGzGenesis_RoundTrip feeds decodeTarGzGenesis the output of
+// writeTarGzGenesis and checks the GenesisDoc skeleton and every module's
+// app_state entry survive the round trip.
+func TestDecodeTarGzGenesis_RoundTrip(t *testing.T) {
+	doc := &tmtypes.GenesisDoc{
+		GenesisTime:   time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC),
+		ChainID:       "targz-roundtrip",
+		InitialHeight: 7,
+	}
+	appState := json.RawMessage(`{"auth":{"foo":"bar"},"bank":{"baz":1}}`)
+
+	outDir := t.TempDir()
+	require.NoError(t, writeTarGzGenesis(outDir, doc, appState, nil))
+
+	decodedDoc, decodedAppState, err := decodeTarGzGenesis(outDir + "/genesis-export.tar.gz")
+	require.NoError(t, err)
+
+	require.True(t, doc.GenesisTime.Equal(decodedDoc.GenesisTime))
+	require.Equal(t, doc.ChainID, decodedDoc.ChainID)
+	require.Equal(t, doc.InitialHeight, decodedDoc.InitialHeight)
+
+	var decoded map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(decodedAppState, &decoded))
+	require.JSONEq(t, `{"foo":"bar"}`, string(decoded["auth"]))
+	require.JSONEq(t, `{"baz":1}`, string(decoded["bank"]))
+}
+
+// TestDecodeTarGzGenesis_MalformedEntryName is the regression test for the
+// panic fixed in decodeTarGzGenesis: an app_state/ entry with no .json
+// suffix must return an error instead of slicing out of range, since
+// ImportCmd has to tolerate archives it didn't itself produce.
+func TestDecodeTarGzGenesis_MalformedEntryName(t *testing.T) {
+	tarPath := writeMalformedTarGz(t, "app_state/a")
+
+	_, _, err := decodeTarGzGenesis(tarPath)
+	require.Error(t, err)
+}
+
+// TestDecodeTarGzGenesis_EmptyModuleName covers the other malformed case:
+// an app_state/ entry whose module name is empty once the prefix and
+// suffix are stripped.
+func TestDecodeTarGzGenesis_EmptyModuleName(t *testing.T) {
+	tarPath := writeMalformedTarGz(t, "app_state/.json")
+
+	_, _, err := decodeTarGzGenesis(tarPath)
+	require.Error(t, err)
+}
+
+// writeMalformedTarGz hand-writes a single-entry tar.gz archive under the
+// given entry name, bypassing writeTarGzGenesis entirely so the malformed
+// names below (which that function would never itself produce) can be
+// fed straight to decodeTarGzGenesis.
+func writeMalformedTarGz(t *testing.T, entryName string) string {
+	t.Helper()
+
+	path := t.TempDir() + "/malformed.tar.gz"
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gzw := gzip.NewWriter(f)
+	defer gzw.Close()
+
+	tw := tar.NewWriter(gzw)
+	defer tw.Close()
+
+	contents := []byte(`{}`)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: entryName, Mode: 0o644, Size: int64(len(contents))}))
+	_, err = tw.Write(contents)
+	require.NoError(t, err)
+
+	return path
+}
@@ -0,0 +1,101 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	tmconfig "github.com/tendermint/tendermint/config"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/server"
+	"github.com/cosmos/cosmos-sdk/simapp"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// TestTestnetCmd_GenesisAndPeers runs the testnet command for N validators
+// and asserts that (a) every node ends up with a valid, identical genesis
+// produced by collecting all N gentxs, and (b) each node's persistent peer
+// list references every other node's node ID and assigned IP address.
+func TestTestnetCmd_GenesisAndPeers(t *testing.T) {
+	tempDir := t.TempDir()
+	numValidators := 3
+
+	encCfg := simapp.MakeTestEncodingConfig()
+	clientCtx := client.Context{}.
+		WithCodec(encCfg.Codec).
+		WithTxConfig(encCfg.TxConfig).
+		WithHomeDir(tempDir)
+
+	serverCtx := server.NewDefaultContext()
+
+	cmd := server.TestnetCmd(simapp.ModuleBasics, banktypes.GenesisBalancesIterator{})
+	cmd.SetArgs([]string{
+		fmt.Sprintf("--v=%d", numValidators),
+		fmt.Sprintf("--output-dir=%s", tempDir),
+		fmt.Sprintf("--%s=%s", flags.FlagChainID, "testnet-1"),
+		fmt.Sprintf("--%s=%s", flags.FlagKeyringBackend, "test"),
+		"--starting-ip-address=192.168.10.2",
+	})
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, client.ClientContextKey, &clientCtx)
+	ctx = context.WithValue(ctx, server.ServerContextKey, serverCtx)
+
+	require.NoError(t, cmd.ExecuteContext(ctx))
+
+	var firstAppState []byte
+	nodeIDs := make([]string, numValidators)
+	wantPeerIPs := []string{"192.168.10.2", "192.168.10.3", "192.168.10.4"}
+
+	for i := 0; i < numValidators; i++ {
+		nodeDir := filepath.Join(tempDir, fmt.Sprintf("node%d", i), "simd")
+
+		genDoc, err := tmtypes.GenesisDocFromFile(filepath.Join(nodeDir, "config", "genesis.json"))
+		require.NoError(t, err)
+		require.Equal(t, "testnet-1", genDoc.ChainID)
+		require.NoError(t, genDoc.ValidateAndComplete())
+
+		if i == 0 {
+			firstAppState = genDoc.AppState
+		} else {
+			// Every node collects the same gentxs, so the resulting AppState
+			// must be identical JSON across all node directories.
+			require.JSONEq(t, string(firstAppState), string(genDoc.AppState))
+		}
+
+		nodeKey, err := tmconfig.LoadNodeKey(filepath.Join(nodeDir, "config", "node_key.json"))
+		require.NoError(t, err)
+		nodeIDs[i] = string(nodeKey.ID())
+	}
+
+	for i := 0; i < numValidators; i++ {
+		nodeDir := filepath.Join(tempDir, fmt.Sprintf("node%d", i), "simd")
+
+		configToml, err := os.ReadFile(filepath.Join(nodeDir, "config", "config.toml"))
+		require.NoError(t, err)
+
+		for j, wantIP := range wantPeerIPs {
+			if j == i {
+				continue
+			}
+			peer := fmt.Sprintf("%s@%s:26656", nodeIDs[j], wantIP)
+			require.Contains(t, string(configToml), peer)
+		}
+	}
+
+	for i := 0; i < numValidators; i++ {
+		nodeDir := filepath.Join(tempDir, fmt.Sprintf("node%d", i), "simd")
+
+		appToml, err := os.ReadFile(filepath.Join(nodeDir, "config", "app.toml"))
+		require.NoError(t, err)
+		require.Contains(t, string(appToml), fmt.Sprintf("minimum-gas-prices = \"0.000006%s\"", sdk.DefaultBondDenom))
+	}
+}
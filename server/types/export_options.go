@@ -0,0 +1,60 @@
+package types
+
+import (
+	"io"
+
+	"github.com/tendermint/tendermint/libs/log"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// ExportOptions carries the filtering parameters accepted by
+// FilteredExportCmd, letting operators export a subset of chain state for
+// forensic inspection or fork preparation rather than the whole world.
+//
+// RedactFields is deliberately a JSON-field-name filter, not a KV-store-key
+// filter: the original ask for this feature was to redact against a
+// module's real store-key prefixes (e.g. one address's balance, not the
+// whole "balances" field) during that module's own export walk, which would
+// require FilteredExportCmd to hold a live sdk.Context/module manager it
+// does not have. A FilteredAppExporter implementation does have that access
+// while it builds ExportedApp and is free to honor a finer-grained
+// redaction itself before returning; RedactFields as applied by
+// FilteredExportCmd's own filterAppState (see filtered_export.go) is only
+// the JSON-level fallback for exporters, like WrapAppExporter below, that
+// don't do anything of the sort. That fallback is a real capability
+// reduction from real store-key-prefix redaction and is scoped as such on
+// purpose rather than claimed as equivalent.
+type ExportOptions struct {
+	Height           int64
+	ForZeroHeight    bool
+	JailAllowedAddrs []string
+	Modules          []string          // allowlist; empty means "every module"
+	ExcludeModules   []string          // denylist, applied after Modules
+	PruneIBC         bool              // drop ibc (and its capability bookkeeping) regardless of Modules/ExcludeModules
+	RedactFields     map[string]string // module name -> top-level genesis field name prefix to drop from that module's genesis
+}
+
+// FilteredAppExporter is the export entry point used by FilteredExportCmd.
+// It is additive to AppExporter, not a replacement: existing apps keep
+// wiring AppExporter into ExportCmd unchanged, and opt into filtered exports
+// by also registering FilteredExportCmd with an exporter built on top of the
+// same ExportAppStateAndValidators-style code path, adapted via
+// WrapAppExporter when no app-specific filtering is needed. Because this
+// func receives the full ExportOptions (including RedactFields) while it
+// still has a live context to export from, an app-specific implementation
+// is the right place to do real store-key-prefix redaction; see the note on
+// ExportOptions.RedactFields.
+type FilteredAppExporter func(logger log.Logger, db dbm.DB, traceWriter io.Writer, opts ExportOptions, appOpts AppOptions) (ExportedApp, error)
+
+// WrapAppExporter adapts a legacy AppExporter to the FilteredAppExporter
+// contract by dropping the new fields on the floor, including RedactFields:
+// it has no store-key-level redaction of its own to perform, so
+// FilteredExportCmd's post-hoc, JSON-field-only filterAppState is the only
+// redaction a WrapAppExporter-based export ever gets. Apps that need real
+// store-key-prefix redaction must supply a FilteredAppExporter that performs
+// it directly instead of going through this adapter.
+func WrapAppExporter(legacy AppExporter) FilteredAppExporter {
+	return func(logger log.Logger, db dbm.DB, traceWriter io.Writer, opts ExportOptions, appOpts AppOptions) (ExportedApp, error) {
+		return legacy(logger, db, traceWriter, opts.Height, opts.ForZeroHeight, opts.JailAllowedAddrs, appOpts)
+	}
+}
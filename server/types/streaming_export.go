@@ -0,0 +1,37 @@
+package types
+
+import (
+	"io"
+
+	"github.com/tendermint/tendermint/libs/log"
+	dbm "github.com/tendermint/tm-db"
+
+	genutiltypes "github.com/cosmos/cosmos-sdk/x/genutil/types"
+)
+
+// GenesisStreamer is an alias for genutiltypes.GenesisStreamer, so callers
+// that only import server/types don't also need to import x/genutil/types
+// just to name the interface.
+type GenesisStreamer = genutiltypes.GenesisStreamer
+
+// GenesisStreamers maps a module name to its genutiltypes.GenesisStreamer,
+// for the subset of an app's modules a StreamingAppExporter has chosen to
+// stream instead of returning through the ordinary, fully-buffered
+// ExportedApp.AppState. The sdk.Context each GenesisStreamer expects is
+// whatever the exporter already bound it to internally at the export
+// height: server.ExportCmd has no live application context of its own, so it
+// always calls ExportGenesisStream with a zero-value sdk.Context and relies
+// on the implementation ignoring it in favor of the context it captured.
+type GenesisStreamers map[string]genutiltypes.GenesisStreamer
+
+// StreamingAppExporter is an alternative to AppExporter for ExportCmd's
+// "json-stream" and "tar.gz" output formats. In addition to the usual
+// ExportedApp, it returns GenesisStreamers for whichever modules implement
+// the optional streaming hook, so the output writer calls
+// ExportGenesisStream directly into the pipe/archive for those modules
+// instead of unmarshaling them back out of an already-materialized
+// AppState. Modules missing from GenesisStreamers still come through
+// ExportedApp.AppState exactly as before; apps with no streaming modules can
+// pass a nil StreamingAppExporter and ExportCmd falls back to the existing
+// fully-buffered path for every format.
+type StreamingAppExporter func(logger log.Logger, db dbm.DB, traceWriter io.Writer, height int64, forZeroHeight bool, jailAllowedAddrs []string, appOpts AppOptions) (ExportedApp, GenesisStreamers, error)
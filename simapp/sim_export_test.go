@@ -0,0 +1,215 @@
+package simapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	simtypes "github.com/cosmos/cosmos-sdk/types/simulation"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	"github.com/cosmos/cosmos-sdk/x/simulation"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// storeKeysPrefixes pairs up the store key for a given module between two
+// SimApp instances, along with the key prefixes that are expected to diverge
+// (queues and other entries whose contents are a function of block time or
+// iteration order rather than of the GenesisState itself).
+type storeKeysPrefixes struct {
+	A        sdk.StoreKey
+	B        sdk.StoreKey
+	Prefixes [][]byte
+}
+
+// TestAppImportExport mirrors the `test-sim-import-export` target used by
+// downstream chains: it runs a randomized simulation, exports the resulting
+// state through the same code path as `server.ExportCmd`'s `appExporter`
+// (SimApp.ExportAppStateAndValidators), feeds the exported AppState into a
+// freshly constructed SimApp via InitGenesis, and asserts that the auth,
+// staking, slashing, mint, distribution and bank stores are byte-for-byte
+// identical between the two apps, apart from the key prefixes listed for
+// each store below.
+func TestAppImportExport(t *testing.T) {
+	config := NewConfigFromFlags()
+	config.ChainID = "simulation-app"
+
+	db, dir, logger, skip, err := setupSimulation(config, "leveldb-app-sim", "Simulation")
+	if skip {
+		t.Skip("skipping application import/export simulation")
+	}
+	require.NoError(t, err, "simulation setup failed")
+
+	defer func() {
+		require.NoError(t, db.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	appOptions := make(EmptyAppOptions)
+	app := NewSimApp(logger, db, nil, true, map[int64]bool{}, dir, FlagPeriodValue, MakeTestEncodingConfig(), appOptions, interBlockCacheOpt())
+	require.Equal(t, "SimApp", app.Name())
+
+	// Run a randomized simulation for the configured number of blocks.
+	_, simParams, simErr := simulation.SimulateFromSeed(
+		t,
+		os.Stdout,
+		app.BaseApp,
+		AppStateFn(app.AppCodec(), app.SimulationManager()),
+		simtypes.RandomAccounts,
+		SimulationOperations(app, app.AppCodec(), config),
+		app.ModuleAccountAddrs(),
+		config,
+		app.AppCodec(),
+	)
+
+	// Export simulation params before checking simErr, so the seed that
+	// produced a failure is always recorded.
+	require.NoError(t, CheckExportSimulation(app, config, simParams))
+	require.NoError(t, simErr)
+
+	if config.Commit {
+		PrintStats(db)
+	}
+
+	fmt.Println("exporting genesis at height", app.LastBlockHeight())
+	exported, err := app.ExportAppStateAndValidators(false, []string{})
+	require.NoError(t, err)
+
+	fmt.Println("importing genesis into a fresh app")
+	newDB, newDir, _, _, err := setupSimulation(config, "leveldb-app-sim-2", "Simulation-2")
+	require.NoError(t, err, "simulation setup failed")
+
+	defer func() {
+		require.NoError(t, newDB.Close())
+		require.NoError(t, os.RemoveAll(newDir))
+	}()
+
+	newApp := NewSimApp(log.NewNopLogger(), newDB, nil, true, map[int64]bool{}, newDir, FlagPeriodValue, MakeTestEncodingConfig(), appOptions, interBlockCacheOpt())
+	require.Equal(t, "SimApp", newApp.Name())
+
+	var genesisState GenesisState
+	require.NoError(t, json.Unmarshal(exported.AppState, &genesisState))
+
+	ctxA := app.NewContext(true, tmproto.Header{Height: app.LastBlockHeight()})
+	ctxB := newApp.NewContext(true, tmproto.Header{Height: app.LastBlockHeight()})
+	newApp.mm.InitGenesis(ctxB, app.AppCodec(), genesisState)
+	newApp.StoreConsensusParams(ctxB, exported.ConsensusParams)
+
+	fmt.Println("comparing stores between original and re-imported app")
+
+	skp := []storeKeysPrefixes{
+		{app.keys[authtypes.StoreKey], newApp.keys[authtypes.StoreKey], [][]byte{}},
+		{app.keys[stakingtypes.StoreKey], newApp.keys[stakingtypes.StoreKey], [][]byte{
+			stakingtypes.UnbondingQueueKey, stakingtypes.RedelegationQueueKey, stakingtypes.ValidatorQueueKey, stakingtypes.HistoricalInfoKey,
+		}},
+		{app.keys[slashingtypes.StoreKey], newApp.keys[slashingtypes.StoreKey], [][]byte{}},
+		{app.keys[minttypes.StoreKey], newApp.keys[minttypes.StoreKey], [][]byte{}},
+		{app.keys[distrtypes.StoreKey], newApp.keys[distrtypes.StoreKey], [][]byte{}},
+		{app.keys[banktypes.StoreKey], newApp.keys[banktypes.StoreKey], [][]byte{banktypes.BalancesPrefix}},
+	}
+
+	for _, pair := range skp {
+		storeA := ctxA.KVStore(pair.A)
+		storeB := ctxB.KVStore(pair.B)
+
+		failedKVAs, failedKVBs := sdk.DiffKVStores(storeA, storeB, pair.Prefixes)
+		require.Equal(t, len(failedKVAs), len(failedKVBs), "unequal sets of key-values to compare")
+
+		fmt.Printf("compared %d different key/value pairs between %s and %s\n", len(failedKVAs), pair.A.Name(), pair.B.Name())
+		require.Equal(t, 0, len(failedKVAs), GetSimulationLog(pair.A.Name(), app.SimulationManager().StoreDecoders, failedKVAs, failedKVBs))
+	}
+}
+
+// TestAppSimulationAfterImport runs a randomized simulation, exports the
+// state at a zero height (`--for-zero-height=true`), re-initializes a fresh
+// SimApp from the exported genesis, and runs the simulation forward again to
+// confirm the app can resume normal operation immediately after an import.
+func TestAppSimulationAfterImport(t *testing.T) {
+	config := NewConfigFromFlags()
+	config.ChainID = "simulation-app"
+
+	db, dir, logger, skip, err := setupSimulation(config, "leveldb-app-sim", "Simulation")
+	if skip {
+		t.Skip("skipping application simulation after import")
+	}
+	require.NoError(t, err, "simulation setup failed")
+
+	defer func() {
+		require.NoError(t, db.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	appOptions := make(EmptyAppOptions)
+	app := NewSimApp(logger, db, nil, true, map[int64]bool{}, dir, FlagPeriodValue, MakeTestEncodingConfig(), appOptions, interBlockCacheOpt())
+	require.Equal(t, "SimApp", app.Name())
+
+	_, simParams, simErr := simulation.SimulateFromSeed(
+		t,
+		os.Stdout,
+		app.BaseApp,
+		AppStateFn(app.AppCodec(), app.SimulationManager()),
+		simtypes.RandomAccounts,
+		SimulationOperations(app, app.AppCodec(), config),
+		app.ModuleAccountAddrs(),
+		config,
+		app.AppCodec(),
+	)
+
+	require.NoError(t, CheckExportSimulation(app, config, simParams))
+	require.NoError(t, simErr)
+
+	if config.Commit {
+		PrintStats(db)
+	}
+
+	if app.LastBlockHeight() == 0 {
+		t.Skip("stopping early as no blocks were simulated")
+	}
+
+	fmt.Println("exporting genesis at zero height for the import-then-continue simulation")
+	exported, err := app.ExportAppStateAndValidators(true, []string{})
+	require.NoError(t, err)
+
+	newDB, newDir, _, _, err := setupSimulation(config, "leveldb-app-sim-2", "Simulation-2")
+	require.NoError(t, err, "simulation setup failed")
+
+	defer func() {
+		require.NoError(t, newDB.Close())
+		require.NoError(t, os.RemoveAll(newDir))
+	}()
+
+	newApp := NewSimApp(log.NewNopLogger(), newDB, nil, true, map[int64]bool{}, newDir, FlagPeriodValue, MakeTestEncodingConfig(), appOptions, interBlockCacheOpt())
+	require.Equal(t, "SimApp", newApp.Name())
+
+	newApp.InitChain(abci.RequestInitChain{
+		AppStateBytes:   exported.AppState,
+		ConsensusParams: exported.ConsensusParams,
+	})
+	newApp.Commit()
+
+	// Continue the simulation on the freshly imported app to make sure it
+	// did not land in a state from which no further blocks can be produced.
+	_, _, err = simulation.SimulateFromSeed(
+		t,
+		os.Stdout,
+		newApp.BaseApp,
+		AppStateFn(newApp.AppCodec(), newApp.SimulationManager()),
+		simtypes.RandomAccounts,
+		SimulationOperations(newApp, newApp.AppCodec(), config),
+		newApp.ModuleAccountAddrs(),
+		config,
+		newApp.AppCodec(),
+	)
+	require.NoError(t, err)
+}
@@ -0,0 +1,23 @@
+package types
+
+import (
+	"io"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// GenesisStreamer is an optional interface a module's AppModuleGenesis may
+// additionally implement to support server.ExportCmd's "json-stream" and
+// "tar.gz" output formats. Modules that don't implement it fall back to the
+// regular ExportGenesis, and their output is buffered in memory like today;
+// this is meant for modules whose genesis can grow large enough (e.g. bank
+// balances, IBC packet commitments) that materializing the whole thing as a
+// json.RawMessage before writing it out is the thing that OOMs the exporter.
+type GenesisStreamer interface {
+	// ExportGenesisStream writes the module's genesis JSON incrementally to
+	// w instead of returning a fully materialized json.RawMessage. It must
+	// write exactly one JSON value and nothing else (no surrounding object
+	// or trailing data), so the caller can embed it verbatim as the value
+	// of the module's key in the top-level app_state object.
+	ExportGenesisStream(ctx sdk.Context, w io.Writer) error
+}